@@ -2,10 +2,40 @@ package failuredetector
 
 import "time"
 
+// Clock supplies the current time to a PhiAccuralFailureDetector. It exists so
+// that callers can substitute a deterministic time source in tests, or a
+// monotonic one (see MonotonicClock) in production, in place of time.Now.
+type Clock interface {
+	Now() time.Time
+}
+
+// clock adapts a plain func() time.Time, such as the fakeClock used in tests, to
+// the public Clock interface.
 type clock func() time.Time
 
-func defaultClock() time.Time {
-	return time.Now()
+func (c clock) Now() time.Time {
+	return c()
+}
+
+func defaultClock() Clock {
+	return clock(time.Now)
+}
+
+// MonotonicClock is a Clock whose Now derives from time.Since a fixed epoch,
+// making it immune to wall-clock adjustments such as NTP corrections or VM
+// pauses, unlike time.Now used directly.
+type MonotonicClock struct {
+	epoch time.Time
+}
+
+// NewMonotonicClock creates a MonotonicClock anchored to the current time.
+func NewMonotonicClock() *MonotonicClock {
+	return &MonotonicClock{epoch: time.Now()}
+}
+
+// Now returns the epoch plus elapsed monotonic time since it was created.
+func (c *MonotonicClock) Now() time.Time {
+	return c.epoch.Add(time.Since(c.epoch))
 }
 
 type fakeClock struct {
@@ -28,3 +28,14 @@ func TestFakeClock(t *testing.T) {
 
 	}
 }
+
+func TestMonotonicClockIsNonDecreasing(t *testing.T) {
+	c := NewMonotonicClock()
+
+	first := c.Now()
+	second := c.Now()
+
+	if second.Before(first) {
+		t.Errorf("MonotonicClock.Now() went backwards: %v then %v", first, second)
+	}
+}
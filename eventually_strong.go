@@ -0,0 +1,157 @@
+package failuredetector
+
+import (
+	"sort"
+	"sync"
+)
+
+// defaultThresholdScale is the per-peer threshold multiplier a node starts with
+// before any false suspicion has been observed against that peer.
+const defaultThresholdScale = 1.0
+
+// falseSuspicionGrowth is how much a peer's threshold multiplier grows each time
+// another node's reported leader contradicts our suspicion of it.
+const falseSuspicionGrowth = 2.0
+
+// LeaderMessage is exchanged between nodes running an EventuallyStrongDetector so
+// each can learn its peers' current trusted leader. Upper layers are responsible
+// for shipping it over their own transport; this package only defines its shape
+// and the state machine that consumes it.
+type LeaderMessage struct {
+	Sender        NodeID
+	TrustedLeader NodeID
+}
+
+// LeaderChanged is emitted whenever a node's locally elected leader changes.
+type LeaderChanged struct {
+	Leader NodeID
+}
+
+// EventuallyStrongDetector builds a rotating-leader ◇S-style failure detector on
+// top of a Registry, using the technique from Larrea, Fernández and Arévalo's
+// implementation of the weakest failure detector for consensus. Each node
+// maintains a total order over the known membership and trusts the lowest-ID
+// member it does not currently suspect via the registry's phi values; when the
+// current leader is suspected, the node advances to the next untrusted candidate.
+//
+// To make the detector eventually strong, a peer's disagreement about who the
+// leader is treated as evidence that our own suspicion of its candidate was
+// false: EventuallyStrongDetector grows that candidate's threshold multiplier so
+// it takes a higher phi to suspect it again, the same way accrual detectors grow
+// more tolerant as the observed distribution widens.
+//
+// EventuallyStrongDetector doesn't own any networking; callers are responsible
+// for broadcasting Message and feeding peer messages back in through Receive.
+type EventuallyStrongDetector struct {
+	self     NodeID
+	registry *Registry
+
+	mu             sync.Mutex
+	members        []NodeID
+	thresholdScale map[NodeID]float64
+	leader         NodeID
+	events         chan LeaderChanged
+}
+
+// NewEventuallyStrongDetector creates a detector for self that elects a leader
+// from members using registry's phi-based suspicion of each member.
+func NewEventuallyStrongDetector(self NodeID, members []NodeID, registry *Registry) *EventuallyStrongDetector {
+	sorted := append([]NodeID(nil), members...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	d := &EventuallyStrongDetector{
+		self:           self,
+		registry:       registry,
+		members:        sorted,
+		thresholdScale: make(map[NodeID]float64),
+		events:         make(chan LeaderChanged, 1),
+	}
+	d.leader = d.electLocked()
+
+	return d
+}
+
+// Events returns the channel on which LeaderChanged is delivered.
+func (d *EventuallyStrongDetector) Events() <-chan LeaderChanged {
+	return d.events
+}
+
+// Leader returns the node this detector currently trusts as leader.
+func (d *EventuallyStrongDetector) Leader() NodeID {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.leader
+}
+
+// Message returns the LeaderMessage this node should broadcast to its peers to
+// advertise its currently trusted leader.
+func (d *EventuallyStrongDetector) Message() LeaderMessage {
+	return LeaderMessage{Sender: d.self, TrustedLeader: d.Leader()}
+}
+
+// Reelect re-evaluates the locally trusted leader against the registry's current
+// phi values and emits LeaderChanged if it changed. Callers should invoke this
+// after each Registry.Update, or on their own timer.
+func (d *EventuallyStrongDetector) Reelect() NodeID {
+	d.mu.Lock()
+	newLeader := d.electLocked()
+	changed := newLeader != d.leader
+	d.leader = newLeader
+	d.mu.Unlock()
+
+	if changed {
+		select {
+		case d.events <- LeaderChanged{Leader: newLeader}:
+		default:
+		}
+	}
+
+	return newLeader
+}
+
+// Receive processes a LeaderMessage from a peer. If the peer trusts a leader we
+// currently suspect, that's evidence our suspicion of it was false, so its
+// threshold multiplier is grown to make us slower to suspect it again.
+func (d *EventuallyStrongDetector) Receive(msg LeaderMessage) {
+	d.mu.Lock()
+	if msg.TrustedLeader != d.leader && d.suspectsLocked(msg.TrustedLeader) {
+		d.thresholdScale[msg.TrustedLeader] = d.scaleLocked(msg.TrustedLeader) * falseSuspicionGrowth
+	}
+	d.mu.Unlock()
+
+	d.Reelect()
+}
+
+// electLocked returns the lowest-ID member not currently suspected. Callers must
+// hold d.mu.
+func (d *EventuallyStrongDetector) electLocked() NodeID {
+	for _, id := range d.members {
+		if !d.suspectsLocked(id) {
+			return id
+		}
+	}
+
+	// self is never suspected, so this is only reached when self is not among
+	// members and every member is suspected; trust ourselves so the system can
+	// still make progress
+	return d.self
+}
+
+// suspectsLocked reports whether id is currently suspected of having failed.
+// Callers must hold d.mu.
+func (d *EventuallyStrongDetector) suspectsLocked(id NodeID) bool {
+	if id == d.self {
+		return false
+	}
+
+	return d.registry.Phi(id) >= d.registry.Threshold()*d.scaleLocked(id)
+}
+
+// scaleLocked returns id's current threshold multiplier. Callers must hold d.mu.
+func (d *EventuallyStrongDetector) scaleLocked(id NodeID) float64 {
+	if scale, ok := d.thresholdScale[id]; ok {
+		return scale
+	}
+
+	return defaultThresholdScale
+}
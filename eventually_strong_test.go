@@ -0,0 +1,87 @@
+package failuredetector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventuallyStrongDetectorElectsLowestIDWhenNoneSuspected(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+	members := []NodeID{"c", "a", "b"}
+
+	d := NewEventuallyStrongDetector("a", members, r)
+
+	if leader := d.Leader(); leader != "a" {
+		t.Errorf("Leader() = %v, want a", leader)
+	}
+}
+
+func TestReceiveDoesNotGrowThresholdWhenNotSuspected(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+	members := []NodeID{"a", "b"}
+
+	d := NewEventuallyStrongDetector("b", members, r)
+
+	if leader := d.Leader(); leader != "a" {
+		t.Errorf("Leader() = %v, want a", leader)
+	}
+
+	// peer still trusts "a", but "b" isn't suspecting "a" yet, so there's no
+	// false suspicion to correct for
+	d.Receive(LeaderMessage{Sender: "c", TrustedLeader: "a"})
+
+	if scale := d.scaleLocked("a"); scale != defaultThresholdScale {
+		t.Errorf("scaleLocked(a) = %v, want unchanged since a wasn't suspected", scale)
+	}
+}
+
+// TestReceiveGrowsThresholdOnDisagreement exercises the actual ◇S mechanism:
+// once this node suspects the current leader but a peer reports it's still
+// trusting that leader, the disagreement should be treated as evidence of a
+// false suspicion and grow that leader's threshold multiplier. It drives "a"
+// quiet via an injected fake clock rather than a real sleep, so the test is
+// deterministic.
+func TestReceiveGrowsThresholdOnDisagreement(t *testing.T) {
+	// consumed in order: ReportHeartbeat, the constructor's initial election,
+	// Reelect, suspectsLocked, Receive's own suspicion check, and the Reelect
+	// Receive triggers afterwards
+	c := newFakeClock([]int{0, 1, 500, 0, 0, 0})
+
+	config := DetectorConfig{
+		Threshold:                1.0,
+		MaxSampleSize:            1000,
+		MinStdDeviation:          time.Millisecond,
+		AcceptableHeartbeatPause: 0,
+		FirstHeartbeatEstimate:   5 * time.Millisecond,
+		Clock:                    c,
+	}
+	r := NewRegistry(config, 0)
+	members := []NodeID{"a", "b"}
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	d := NewEventuallyStrongDetector("b", members, r)
+
+	if leader := d.Leader(); leader != "a" {
+		t.Fatalf("Leader() = %v, want a before a goes quiet", leader)
+	}
+
+	// the fake clock's next tick jumps 500ms ahead, long enough relative to a's
+	// tiny firstHeartbeatEstimate that "b" starts suspecting it
+	if leader := d.Reelect(); leader != "b" {
+		t.Fatalf("Reelect() = %v, want b once a is suspected", leader)
+	}
+
+	if !d.suspectsLocked("a") {
+		t.Fatal("suspectsLocked(a) = false, want true once a has gone quiet")
+	}
+
+	// a peer still trusts "a" as leader, contradicting our suspicion of it
+	d.Receive(LeaderMessage{Sender: "c", TrustedLeader: "a"})
+
+	if scale := d.scaleLocked("a"); scale != falseSuspicionGrowth {
+		t.Errorf("scaleLocked(a) = %v, want %v after a disagreeing peer message", scale, falseSuspicionGrowth)
+	}
+}
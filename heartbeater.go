@@ -0,0 +1,236 @@
+package failuredetector
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// maxSendBackoff bounds how long SendLoop will back off after repeated send
+// failures before retrying.
+const maxSendBackoff = time.Minute
+
+// Detector is the subset of PhiAccuralFailureDetector's API a Heartbeater needs,
+// letting it drive any compatible failure detector.
+type Detector interface {
+	Heartbeat()
+}
+
+// HeartbeaterConfig configures the inbound side of a Heartbeater. Any
+// combination of Reader, Signal and Ticker may be left nil/zero; a Heartbeater
+// with none of them set only drives the outbound side via SendLoop.
+type HeartbeaterConfig struct {
+	// Reader, if set, is read from continuously; every successful read is
+	// treated as a heartbeat. If Reader also implements io.Closer (as a
+	// net.Conn does), Close closes it to unblock a Read that's parked waiting
+	// for bytes from a peer that has gone silent; otherwise Close can block
+	// until the underlying Read call returns on its own.
+	Reader io.Reader
+	// Signal, if set, is a channel on which any received value is treated as a
+	// heartbeat.
+	Signal <-chan struct{}
+	// Ticker, if set, is treated as a heartbeat source in its own right, for
+	// resources whose liveness is inferred rather than reported.
+	Ticker *time.Ticker
+	// GapThreshold, if positive, marks a received heartbeat as a gap in Stats
+	// when more than GapThreshold has elapsed since the previous one.
+	GapThreshold time.Duration
+}
+
+// Stats reports a Heartbeater's inbound/outbound activity.
+type Stats struct {
+	HeartbeatsReceived uint64
+	HeartbeatsSent     uint64
+	ReceiveGaps        uint64
+	LastSendError      error
+}
+
+// Heartbeater drives a Detector's Heartbeat() from one or more inbound sources,
+// and optionally sends outbound heartbeats at a fixed cadence via SendLoop, so
+// applications can wire a full bidirectional heartbeat with one type instead of
+// reimplementing the read/send loop for each connection.
+type Heartbeater struct {
+	detector     Detector
+	reader       io.Reader
+	gapThreshold time.Duration
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+
+	mu          sync.Mutex
+	received    uint64
+	sent        uint64
+	gaps        uint64
+	lastSendErr error
+	lastReceive time.Time
+}
+
+// NewHeartbeater creates a Heartbeater that calls detector.Heartbeat() whenever
+// bytes arrive on cfg.Reader, a value arrives on cfg.Signal, or cfg.Ticker fires.
+func NewHeartbeater(detector Detector, cfg HeartbeaterConfig) *Heartbeater {
+	h := &Heartbeater{
+		detector:     detector,
+		reader:       cfg.Reader,
+		gapThreshold: cfg.GapThreshold,
+		closeCh:      make(chan struct{}),
+	}
+
+	if cfg.Reader != nil {
+		h.wg.Add(1)
+		go h.readLoop(cfg.Reader)
+	}
+
+	if cfg.Signal != nil || cfg.Ticker != nil {
+		h.wg.Add(1)
+		go h.signalLoop(cfg.Signal, cfg.Ticker)
+	}
+
+	return h
+}
+
+func (h *Heartbeater) readLoop(r io.Reader) {
+	defer h.wg.Done()
+
+	buf := make([]byte, 256)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			h.onHeartbeat()
+		}
+
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-h.closeCh:
+			return
+		default:
+		}
+	}
+}
+
+func (h *Heartbeater) signalLoop(signal <-chan struct{}, ticker *time.Ticker) {
+	defer h.wg.Done()
+
+	var tickC <-chan time.Time
+	if ticker != nil {
+		tickC = ticker.C
+	}
+
+	for {
+		select {
+		case <-h.closeCh:
+			return
+		case _, ok := <-signal:
+			if !ok {
+				signal = nil
+				continue
+			}
+			h.onHeartbeat()
+		case <-tickC:
+			h.onHeartbeat()
+		}
+	}
+}
+
+func (h *Heartbeater) onHeartbeat() {
+	now := time.Now()
+
+	h.mu.Lock()
+	if h.gapThreshold > 0 && !h.lastReceive.IsZero() && now.Sub(h.lastReceive) > h.gapThreshold {
+		h.gaps++
+	}
+	h.lastReceive = now
+	h.received++
+	h.mu.Unlock()
+
+	h.detector.Heartbeat()
+}
+
+// SendLoop invokes send at roughly interval, jittered by up to half an interval
+// to avoid synchronizing many Heartbeaters on the same cadence, until ctx is
+// cancelled or Close is called. A failing send backs off exponentially, up to
+// maxSendBackoff, before resuming the normal cadence on the next success.
+// Callers typically run SendLoop in its own goroutine.
+func (h *Heartbeater) SendLoop(ctx context.Context, interval time.Duration, send func() error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	backoff := interval
+
+	for {
+		wait := interval/2 + time.Duration(rand.Int63n(int64(interval)/2+1))
+		if h.lastSendFailed() {
+			wait = backoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closeCh:
+			return
+		case <-time.After(wait):
+		}
+
+		err := send()
+
+		h.mu.Lock()
+		h.lastSendErr = err
+		if err == nil {
+			h.sent++
+		}
+		h.mu.Unlock()
+
+		if err != nil {
+			backoff *= 2
+			if backoff > maxSendBackoff {
+				backoff = maxSendBackoff
+			}
+		} else {
+			backoff = interval
+		}
+	}
+}
+
+func (h *Heartbeater) lastSendFailed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastSendErr != nil
+}
+
+// Stats returns a snapshot of the Heartbeater's inbound/outbound activity.
+func (h *Heartbeater) Stats() Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return Stats{
+		HeartbeatsReceived: h.received,
+		HeartbeatsSent:     h.sent,
+		ReceiveGaps:        h.gaps,
+		LastSendError:      h.lastSendErr,
+	}
+}
+
+// Close stops all inbound loops started by NewHeartbeater and any in-flight
+// SendLoop calls. If the configured Reader also implements io.Closer, Close
+// closes it too, so readLoop's pending Read is unblocked rather than leaving
+// Close to hang waiting for bytes that will never come from a peer that has
+// gone silent. It is safe to call more than once.
+func (h *Heartbeater) Close() error {
+	h.closeOnce.Do(func() {
+		close(h.closeCh)
+
+		if closer, ok := h.reader.(io.Closer); ok {
+			closer.Close()
+		}
+	})
+	h.wg.Wait()
+
+	return nil
+}
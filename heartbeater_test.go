@@ -0,0 +1,105 @@
+package failuredetector
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingDetector struct {
+	count int64
+}
+
+func (d *countingDetector) Heartbeat() {
+	atomic.AddInt64(&d.count, 1)
+}
+
+func TestHeartbeaterDrivesDetectorFromSignal(t *testing.T) {
+	detector := &countingDetector{}
+	signal := make(chan struct{})
+
+	h := NewHeartbeater(detector, HeartbeaterConfig{Signal: signal})
+	defer h.Close()
+
+	signal <- struct{}{}
+	signal <- struct{}{}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&detector.count) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if count := atomic.LoadInt64(&detector.count); count != 2 {
+		t.Errorf("detector.Heartbeat() called %v times, want 2", count)
+	}
+
+	if received := h.Stats().HeartbeatsReceived; received != 2 {
+		t.Errorf("Stats().HeartbeatsReceived = %v, want 2", received)
+	}
+}
+
+func TestHeartbeaterCloseStopsSignalLoop(t *testing.T) {
+	detector := &countingDetector{}
+	signal := make(chan struct{})
+
+	h := NewHeartbeater(detector, HeartbeaterConfig{Signal: signal})
+
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case signal <- struct{}{}:
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if count := atomic.LoadInt64(&detector.count); count != 0 {
+		t.Errorf("detector.Heartbeat() called after Close(), count = %v", count)
+	}
+}
+
+func TestHeartbeaterDrivesDetectorFromReader(t *testing.T) {
+	detector := &countingDetector{}
+	r, w := io.Pipe()
+
+	h := NewHeartbeater(detector, HeartbeaterConfig{Reader: r})
+	defer h.Close()
+
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt64(&detector.count) < 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if count := atomic.LoadInt64(&detector.count); count != 1 {
+		t.Errorf("detector.Heartbeat() called %v times, want 1", count)
+	}
+
+	w.Close()
+}
+
+// TestHeartbeaterCloseUnblocksPendingRead confirms Close doesn't hang forever
+// when the Reader is a live connection whose peer has gone silent, i.e. Read is
+// parked with nothing ever arriving and nothing ever closing the writer side.
+func TestHeartbeaterCloseUnblocksPendingRead(t *testing.T) {
+	detector := &countingDetector{}
+	r, _ := io.Pipe() // no writer, and never closed independently of Close
+
+	h := NewHeartbeater(detector, HeartbeaterConfig{Reader: r})
+
+	done := make(chan struct{})
+	go func() {
+		h.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not return within 2s while Reader's Read was blocked")
+	}
+}
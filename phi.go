@@ -6,6 +6,7 @@ package failuredetector
 import (
 	"errors"
 	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -58,13 +59,62 @@ type PhiAccuralFailureDetector struct {
 	firstHeartbeat             heartbeatHistory
 	acceptableHeartbeatPauseMS uint64
 	minStdDeviationMS          uint64
+	minSamples                 uint
+	clock                      Clock
+	maxClockSkew               time.Duration
 	state                      *state
+
+	subsMu          sync.Mutex
+	subs            map[uint64]*subscriber
+	nextSubID       uint64
+	tickerStop      chan struct{}
+	lastAvailable   bool
+	lastAvailableOK bool
 }
 
+// ErrSkewRejected is returned by HeartbeatAt when the reported timestamp exceeds
+// MaxClockSkew from the detector's current time.
+var ErrSkewRejected = errors.New("failuredetector: heartbeat timestamp exceeds max clock skew")
+
 // state of the PhiAccuralFailureDetector
 type state struct {
-	history   heartbeatHistory
-	timestamp *time.Time
+	history     heartbeatHistory
+	timestamp   *time.Time
+	sampleCount uint
+}
+
+// Option configures optional PhiAccuralFailureDetector behaviour not covered by
+// New's required parameters.
+type Option func(*PhiAccuralFailureDetector)
+
+// WithMinSamples makes Phi report 0.0 and IsAvailable report true until the
+// detector has accumulated at least n real heartbeat samples, not counting the
+// bootstrap pair New seeds from firstHeartbeatEstimate. This avoids spurious
+// suspicions during startup, when that seed's stddev/4 guess is a poor fit for
+// the actual environment. Use SampleCount to check a detector's readiness.
+func WithMinSamples(n uint) Option {
+	return func(d *PhiAccuralFailureDetector) {
+		d.minSamples = n
+	}
+}
+
+// WithClock replaces the detector's time source, which otherwise defaults to
+// wall-clock time.Now. Use MonotonicClock to make measurements immune to
+// backward wall-clock jumps, or a test double for deterministic tests.
+func WithClock(c Clock) Option {
+	return func(d *PhiAccuralFailureDetector) {
+		d.clock = c
+	}
+}
+
+// WithMaxClockSkew rejects any HeartbeatAt timestamp that differs from the
+// detector's clock by more than d in either direction. It has no effect on
+// Heartbeat, which always uses the detector's own clock. The default, 0,
+// disables the check.
+func WithMaxClockSkew(d time.Duration) Option {
+	return func(fd *PhiAccuralFailureDetector) {
+		fd.maxClockSkew = d
+	}
 }
 
 // New creates and returns a new failure detector.
@@ -74,7 +124,8 @@ func New(
 	minStdDeviation time.Duration,
 	acceptableHeartbeatPause time.Duration,
 	firstHeartbeatEstimate time.Duration,
-	eventStream chan<- time.Duration) (*PhiAccuralFailureDetector, error) {
+	eventStream chan<- time.Duration,
+	opts ...Option) (*PhiAccuralFailureDetector, error) {
 
 	if threshold <= 0.0 {
 		return nil, errors.New("threshold must be > 0")
@@ -98,7 +149,7 @@ func New(
 
 	firstHeartbeat := initHeartbeat(maxSampleSize, firstHeartbeatEstimate)
 
-	return &PhiAccuralFailureDetector{
+	d := &PhiAccuralFailureDetector{
 		threshold:                  threshold,
 		maxSampleSize:              maxSampleSize,
 		minStdDeviation:            minStdDeviation,
@@ -108,8 +159,16 @@ func New(
 		firstHeartbeat:             firstHeartbeat,
 		acceptableHeartbeatPauseMS: toMillis(acceptableHeartbeatPause),
 		minStdDeviationMS:          toMillis(minStdDeviation),
+		clock:                      defaultClock(),
 		state:                      &state{history: firstHeartbeat, timestamp: nil},
-	}, nil
+		subs:                       make(map[uint64]*subscriber),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d, nil
 }
 
 // initHeartbeat returns the initial heartbeat guess
@@ -136,7 +195,7 @@ func (d *PhiAccuralFailureDetector) casState(old, new *state) bool {
 
 // IsAvailable returns true if the resource is considered to be up and healthy; false otherwise.
 func (d *PhiAccuralFailureDetector) IsAvailable() bool {
-	return d.isAvailableAt(time.Now())
+	return d.isAvailableAt(d.clock.Now())
 }
 
 func (d *PhiAccuralFailureDetector) isAvailableAt(time time.Time) bool {
@@ -153,11 +212,42 @@ func (d *PhiAccuralFailureDetector) IsMonitoring() bool {
 // Notifies the detector that a heartbeat arrived from the monitored resource.
 // This causes the detector to update its state.
 func (d *PhiAccuralFailureDetector) Heartbeat() {
+	d.heartbeatAt(d.clock.Now())
+}
+
+// HeartbeatAt notifies the detector of a heartbeat reported to have occurred at
+// ts, as measured by the monitored resource itself, rather than by this
+// detector's own clock. If ts is further from the detector's current time than
+// MaxClockSkew in either direction, the heartbeat is discarded and a
+// SkewRejected event is published instead of being folded into the history; this
+// guards against a misconfigured or malicious peer corrupting intervalSum /
+// squaredIntervalSum with wildly out-of-range samples that would otherwise take
+// maxSampleSize heartbeats to age out. A MaxClockSkew of 0 disables the check.
+func (d *PhiAccuralFailureDetector) HeartbeatAt(ts time.Time) error {
+	if d.maxClockSkew > 0 {
+		skew := d.clock.Now().Sub(ts)
+		if skew < 0 {
+			skew = -skew
+		}
+
+		if skew > d.maxClockSkew {
+			d.publishSkewRejected(ts, skew)
+			return ErrSkewRejected
+		}
+	}
+
+	d.heartbeatAt(ts)
+
+	return nil
+}
+
+func (d *PhiAccuralFailureDetector) heartbeatAt(timestamp time.Time) {
 	for {
-		timestamp := time.Now()
 		oldState := d.loadState()
 
 		var newHistory heartbeatHistory
+		var lateInterval time.Duration
+		sampleCount := oldState.sampleCount
 
 		if latestTimestamp := oldState.timestamp; latestTimestamp == nil {
 			// this is heartbeat from a new resource
@@ -166,23 +256,33 @@ func (d *PhiAccuralFailureDetector) Heartbeat() {
 		} else {
 			// this is a known connection
 			interval := timestamp.Sub(*latestTimestamp)
-			// don't use the first heartbeat after failure for the history, since a long pause will skew the stats
-			if d.isAvailableAt(timestamp) {
+			// a backward clock jump (NTP correction, VM pause) would otherwise make
+			// interval negative and poison intervalSum/squaredIntervalSum; treat it
+			// like an unavailable-window heartbeat and drop it from the history
+			if interval < 0 {
+				newHistory = oldState.history
+			} else if d.isAvailableAt(timestamp) {
+				// don't use the first heartbeat after failure for the history, since a long pause will skew the stats
 				intervalMS := toMillis(interval)
-				if intervalMS >= (d.acceptableHeartbeatPauseMS/2) && d.eventStream != nil {
+				if intervalMS >= (d.acceptableHeartbeatPauseMS / 2) {
 					// heartbeat interval is growing too large (by interval)
-					d.eventStream <- interval
+					if d.eventStream != nil {
+						d.eventStream <- interval
+					}
+					lateInterval = interval
 				}
 				newHistory = oldState.history.append(intervalMS)
+				sampleCount++
 			} else {
 				newHistory = oldState.history
 			}
 		}
 
-		newState := &state{history: newHistory, timestamp: &timestamp} // record new timestamp
+		newState := &state{history: newHistory, timestamp: &timestamp, sampleCount: sampleCount} // record new timestamp
 
 		// if we won the race then update else try again
 		if d.casState(oldState, newState) {
+			d.notify(timestamp, lateInterval)
 			break
 		}
 	}
@@ -190,7 +290,7 @@ func (d *PhiAccuralFailureDetector) Heartbeat() {
 
 // Phi (the suspicion level) of the accrual failure detector.
 func (d *PhiAccuralFailureDetector) Phi() float64 {
-	return d.phiAt(time.Now())
+	return d.phiAt(d.clock.Now())
 }
 
 // phiAt a given time of the accrual failure detector.
@@ -202,6 +302,10 @@ func (d *PhiAccuralFailureDetector) phiAt(timestamp time.Time) float64 {
 		return 0.0 // treat unmanaged connections, e.g. with zero heartbeats, as healthy connections
 	}
 
+	if oldState.sampleCount < d.minSamples {
+		return 0.0 // not enough real samples yet to trust mean/stddev, assume healthy
+	}
+
 	timeDiff := timestamp.Sub(*oldTimestamp)
 
 	history := oldState.history
@@ -211,6 +315,12 @@ func (d *PhiAccuralFailureDetector) phiAt(timestamp time.Time) float64 {
 	return phi(float64(toMillis(timeDiff)), mean+float64(d.acceptableHeartbeatPauseMS), stdDeviation)
 }
 
+// SampleCount returns the number of real heartbeat samples folded into the
+// detector's history so far, not counting the bootstrap pair seeded by New.
+func (d *PhiAccuralFailureDetector) SampleCount() uint {
+	return d.loadState().sampleCount
+}
+
 func (d *PhiAccuralFailureDetector) ensureValidStdDeviation(stdDeviation float64) float64 {
 	return math.Max(stdDeviation, float64(d.minStdDeviationMS))
 }
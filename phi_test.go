@@ -49,9 +49,58 @@ func TestNodeMarkedDeadAfterHeartbeatsAreMissed(t *testing.T) {
 		t.Error("detector should report resource available")
 	}
 
-	fd.clock() //5200, but unrelated resource
+	fd.clock.Now() //5200, but unrelated resource
 
 	if fd.IsAvailable() { //1200
 		t.Error("detector shouldn't report resource available")
 	}
 }
+
+func TestMinSamplesGatesSuspicion(t *testing.T) {
+	timeInterval := []int{0, 1000, 100, 100, 4000, 3000}
+	c := newFakeClock(timeInterval)
+
+	fd, err := New(3.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil, WithMinSamples(3))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	fd.clock = c
+
+	fd.Heartbeat() //0, 0 samples
+	fd.Heartbeat() //1000, 1 sample
+
+	if fd.SampleCount() != 1 {
+		t.Errorf("SampleCount() = %v, want 1", fd.SampleCount())
+	}
+
+	fd.clock.Now() //1100, unrelated resource
+
+	if !fd.IsAvailable() { //1200; Phi() below runs at 5200
+		t.Error("detector should report resource available while below MinSamples")
+	}
+
+	if fd.Phi() != 0.0 {
+		t.Errorf("Phi() = %v, want 0.0 while below MinSamples", fd.Phi())
+	}
+}
+
+func TestHeartbeatAtRejectsTimestampsOutsideMaxClockSkew(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil, WithMaxClockSkew(time.Second))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	future := time.Now().Add(time.Hour)
+
+	if err := fd.HeartbeatAt(future); err != ErrSkewRejected {
+		t.Errorf("HeartbeatAt(future) = %v, want ErrSkewRejected", err)
+	}
+
+	if fd.SampleCount() != 0 {
+		t.Errorf("SampleCount() = %v, want 0 after a rejected heartbeat", fd.SampleCount())
+	}
+
+	if err := fd.HeartbeatAt(time.Now()); err != nil {
+		t.Errorf("HeartbeatAt(now) = %v, want nil", err)
+	}
+}
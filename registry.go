@@ -0,0 +1,253 @@
+package failuredetector
+
+import (
+	"sync"
+	"time"
+)
+
+// NodeID identifies a node tracked by a Registry.
+type NodeID string
+
+// DetectorConfig carries the PhiAccuralFailureDetector parameters a Registry uses
+// to lazily create a detector for each node on its first heartbeat. See New for a
+// description of each field. Clock, MinSamples and MaxClockSkew are optional and,
+// when set, are passed through as the matching Option; see WithClock,
+// WithMinSamples and WithMaxClockSkew.
+type DetectorConfig struct {
+	Threshold                float64
+	MaxSampleSize            uint
+	MinStdDeviation          time.Duration
+	AcceptableHeartbeatPause time.Duration
+	FirstHeartbeatEstimate   time.Duration
+	Clock                    Clock
+	MinSamples               uint
+	MaxClockSkew             time.Duration
+}
+
+// RegistryEvent is implemented by the values delivered on a Registry's event
+// channel.
+type RegistryEvent interface {
+	isRegistryEvent()
+}
+
+// NodeUp is emitted when a node transitions from dead to live. A node seen for
+// the first time starts out live and does not produce a NodeUp.
+type NodeUp struct {
+	ID NodeID
+}
+
+func (NodeUp) isRegistryEvent() {}
+
+// NodeDown is emitted when a node transitions from live to dead.
+type NodeDown struct {
+	ID NodeID
+	// LastSeen is the time of the node's last recorded heartbeat.
+	LastSeen time.Time
+}
+
+func (NodeDown) isRegistryEvent() {}
+
+// Registry manages one PhiAccuralFailureDetector per NodeID and maintains the
+// live/dead sets over them, similar to the live_nodes/dead_nodes membership kept
+// by gossip protocols such as chitchat. Detectors are created lazily, on a node's
+// first heartbeat, using a shared DetectorConfig.
+type Registry struct {
+	config              DetectorConfig
+	deadNodeGracePeriod time.Duration
+
+	mu        sync.Mutex
+	detectors map[NodeID]*PhiAccuralFailureDetector
+	live      map[NodeID]bool
+	deadSince map[NodeID]time.Time
+
+	events chan RegistryEvent
+}
+
+// NewRegistry creates a Registry that lazily creates a PhiAccuralFailureDetector
+// per node using config. deadNodeGracePeriod controls how long a dead node is kept
+// around (and can still be queried) before Update garbage-collects it; a zero
+// value disables garbage collection.
+func NewRegistry(config DetectorConfig, deadNodeGracePeriod time.Duration) *Registry {
+	return &Registry{
+		config:              config,
+		deadNodeGracePeriod: deadNodeGracePeriod,
+		detectors:           make(map[NodeID]*PhiAccuralFailureDetector),
+		live:                make(map[NodeID]bool),
+		deadSince:           make(map[NodeID]time.Time),
+		events:              make(chan RegistryEvent, 16),
+	}
+}
+
+// Events returns the channel on which NodeUp/NodeDown transitions are delivered.
+// Transitions are only computed by Update, so callers must call it periodically.
+func (r *Registry) Events() <-chan RegistryEvent {
+	return r.events
+}
+
+// detectorFor returns the detector for id, lazily creating it (and marking id
+// live) on first use.
+func (r *Registry) detectorFor(id NodeID) (*PhiAccuralFailureDetector, error) {
+	if d, ok := r.detectors[id]; ok {
+		return d, nil
+	}
+
+	var opts []Option
+	if r.config.Clock != nil {
+		opts = append(opts, WithClock(r.config.Clock))
+	}
+	if r.config.MinSamples > 0 {
+		opts = append(opts, WithMinSamples(r.config.MinSamples))
+	}
+	if r.config.MaxClockSkew > 0 {
+		opts = append(opts, WithMaxClockSkew(r.config.MaxClockSkew))
+	}
+
+	d, err := New(
+		r.config.Threshold,
+		r.config.MaxSampleSize,
+		r.config.MinStdDeviation,
+		r.config.AcceptableHeartbeatPause,
+		r.config.FirstHeartbeatEstimate,
+		nil,
+		opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.detectors[id] = d
+	r.live[id] = true
+	delete(r.deadSince, id)
+
+	return d, nil
+}
+
+// ReportHeartbeat records a heartbeat for id, lazily creating its detector if this
+// is the first heartbeat seen for id.
+func (r *Registry) ReportHeartbeat(id NodeID) error {
+	r.mu.Lock()
+	d, err := r.detectorFor(id)
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	d.Heartbeat()
+
+	return nil
+}
+
+// Phi returns the suspicion level for id, or 0.0 if id has never been seen.
+func (r *Registry) Phi(id NodeID) float64 {
+	r.mu.Lock()
+	d := r.detectors[id]
+	r.mu.Unlock()
+
+	if d == nil {
+		return 0.0
+	}
+
+	return d.Phi()
+}
+
+// Threshold returns the phi threshold shared by every detector in the registry.
+func (r *Registry) Threshold() float64 {
+	return r.config.Threshold
+}
+
+// LiveNodes returns the ids currently considered live.
+func (r *Registry) LiveNodes() []NodeID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]NodeID, 0, len(r.live))
+	for id, live := range r.live {
+		if live {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// DeadNodes returns the ids currently considered dead.
+func (r *Registry) DeadNodes() []NodeID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]NodeID, 0, len(r.live))
+	for id, live := range r.live {
+		if !live {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// Remove drops id and its detector from the registry entirely.
+func (r *Registry) Remove(id NodeID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.detectors, id)
+	delete(r.live, id)
+	delete(r.deadSince, id)
+}
+
+// Update transitions nodes across the live/dead boundary based on their current
+// phi value, emitting NodeUp/NodeDown on the events channel for each transition,
+// and garbage-collects nodes that have been dead for at least
+// DeadNodeGracePeriod. It should be called periodically, e.g. from a ticker.
+func (r *Registry) Update(now time.Time) {
+	r.mu.Lock()
+
+	var transitions []RegistryEvent
+	var collect []NodeID
+
+	for id, d := range r.detectors {
+		wasLive := r.live[id]
+		isLive := d.isAvailableAt(now)
+
+		if isLive == wasLive {
+			continue
+		}
+
+		r.live[id] = isLive
+
+		if isLive {
+			delete(r.deadSince, id)
+			transitions = append(transitions, NodeUp{ID: id})
+		} else {
+			lastSeen := now
+			if ts := d.loadState().timestamp; ts != nil {
+				lastSeen = *ts
+			}
+			r.deadSince[id] = now
+			transitions = append(transitions, NodeDown{ID: id, LastSeen: lastSeen})
+		}
+	}
+
+	if r.deadNodeGracePeriod > 0 {
+		for id, since := range r.deadSince {
+			if now.Sub(since) >= r.deadNodeGracePeriod {
+				collect = append(collect, id)
+			}
+		}
+	}
+
+	for _, id := range collect {
+		delete(r.detectors, id)
+		delete(r.live, id)
+		delete(r.deadSince, id)
+	}
+
+	r.mu.Unlock()
+
+	for _, ev := range transitions {
+		select {
+		case r.events <- ev:
+		default:
+		}
+	}
+}
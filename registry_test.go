@@ -0,0 +1,155 @@
+package failuredetector
+
+import (
+	"testing"
+	"time"
+)
+
+func testDetectorConfig() DetectorConfig {
+	return DetectorConfig{
+		Threshold:                8.0,
+		MaxSampleSize:            200,
+		MinStdDeviation:          10 * time.Millisecond,
+		AcceptableHeartbeatPause: 0,
+		FirstHeartbeatEstimate:   1 * time.Second,
+	}
+}
+
+func TestRegistryTracksLiveNodesOnHeartbeat(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	live := r.LiveNodes()
+	if len(live) != 1 || live[0] != "a" {
+		t.Errorf("LiveNodes() = %v, want [a]", live)
+	}
+
+	if len(r.DeadNodes()) != 0 {
+		t.Errorf("DeadNodes() = %v, want none", r.DeadNodes())
+	}
+}
+
+func TestRegistryPhiOfUnknownNodeIsZero(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+
+	if phi := r.Phi("unknown"); phi != 0.0 {
+		t.Errorf("Phi(unknown) = %v, want 0.0", phi)
+	}
+}
+
+func TestRegistryRemoveDropsNode(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	r.Remove("a")
+
+	if live := r.LiveNodes(); len(live) != 0 {
+		t.Errorf("LiveNodes() = %v, want none after Remove", live)
+	}
+}
+
+func TestRegistryUpdateEmitsNodeDown(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	// Update should judge liveness as of the now it's given, not real wall time,
+	// so a far-future now deterministically pushes phi past the threshold.
+	r.Update(time.Now().Add(10 * time.Hour))
+
+	select {
+	case ev := <-r.Events():
+		down, ok := ev.(NodeDown)
+		if !ok || down.ID != "a" {
+			t.Errorf("Update() emitted %#v, want NodeDown{ID: a}", ev)
+		}
+	default:
+		t.Error("Update() did not emit a NodeDown transition")
+	}
+
+	dead := r.DeadNodes()
+	if len(dead) != 1 || dead[0] != "a" {
+		t.Errorf("DeadNodes() = %v, want [a]", dead)
+	}
+}
+
+func TestRegistryUpdateEmitsNodeUpOnRecovery(t *testing.T) {
+	r := NewRegistry(testDetectorConfig(), 0)
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	// push "a" dead, then heartbeat it again before the next Update so it should
+	// come back up
+	r.Update(time.Now().Add(10 * time.Hour))
+
+	select {
+	case <-r.Events():
+	default:
+		t.Fatal("Update() did not emit the expected NodeDown transition")
+	}
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	r.Update(time.Now())
+
+	select {
+	case ev := <-r.Events():
+		up, ok := ev.(NodeUp)
+		if !ok || up.ID != "a" {
+			t.Errorf("Update() emitted %#v, want NodeUp{ID: a}", ev)
+		}
+	default:
+		t.Error("Update() did not emit a NodeUp transition")
+	}
+
+	live := r.LiveNodes()
+	if len(live) != 1 || live[0] != "a" {
+		t.Errorf("LiveNodes() = %v, want [a]", live)
+	}
+}
+
+func TestRegistryUpdateGarbageCollectsAfterGracePeriod(t *testing.T) {
+	gracePeriod := time.Minute
+	r := NewRegistry(testDetectorConfig(), gracePeriod)
+
+	if err := r.ReportHeartbeat("a"); err != nil {
+		t.Fatalf("ReportHeartbeat: %v", err)
+	}
+
+	deadAt := time.Now().Add(10 * time.Hour)
+	r.Update(deadAt)
+
+	if dead := r.DeadNodes(); len(dead) != 1 {
+		t.Fatalf("DeadNodes() = %v, want [a] before the grace period elapses", dead)
+	}
+
+	// still within the grace period: node stays around
+	r.Update(deadAt.Add(gracePeriod / 2))
+
+	if dead := r.DeadNodes(); len(dead) != 1 {
+		t.Errorf("DeadNodes() = %v, want [a] still within the grace period", dead)
+	}
+
+	// past the grace period: node is garbage-collected entirely
+	r.Update(deadAt.Add(gracePeriod * 2))
+
+	if dead := r.DeadNodes(); len(dead) != 0 {
+		t.Errorf("DeadNodes() = %v, want none after the grace period elapses", dead)
+	}
+
+	if phi := r.Phi("a"); phi != 0.0 {
+		t.Errorf("Phi(a) = %v, want 0.0 for a garbage-collected node", phi)
+	}
+}
@@ -0,0 +1,102 @@
+package failuredetector
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"time"
+)
+
+// snapshotVersion is bumped whenever the wire format of snapshot changes, so
+// Restore can reject snapshots produced by other versions; it does not migrate
+// them.
+const snapshotVersion = 1
+
+// ErrSnapshotConfigMismatch is returned by Restore when the snapshot was taken
+// from a detector whose maxSampleSize or minStdDeviation differ from this one's;
+// replaying history computed under a different configuration would be
+// meaningless.
+var ErrSnapshotConfigMismatch = errors.New("failuredetector: snapshot config does not match detector config")
+
+// ErrSnapshotVersionMismatch is returned by Restore when the snapshot was
+// produced by a different snapshotVersion than this build understands.
+var ErrSnapshotVersionMismatch = errors.New("failuredetector: snapshot version does not match")
+
+// snapshot is the gob-serializable representation of a PhiAccuralFailureDetector's
+// persisted state.
+type snapshot struct {
+	Version            int
+	MaxSampleSize      uint
+	MinStdDeviation    time.Duration
+	Intervals          []uint64
+	IntervalSum        uint64
+	SquaredIntervalSum uint64
+	Timestamp          *time.Time
+	SampleCount        uint
+}
+
+// Snapshot serializes the detector's current heartbeat history, last timestamp
+// and sample count, so it can be restored later with Restore. This lets a
+// supervisor or cluster node persist detector state across restarts instead of
+// re-entering the high-variance bootstrap phase every time, and lets operators
+// inspect or hand-edit detector state for debugging.
+func (d *PhiAccuralFailureDetector) Snapshot() ([]byte, error) {
+	st := d.loadState()
+
+	s := snapshot{
+		Version:            snapshotVersion,
+		MaxSampleSize:      d.maxSampleSize,
+		MinStdDeviation:    d.minStdDeviation,
+		Intervals:          append([]uint64(nil), st.history.intervals...),
+		IntervalSum:        st.history.intervalSum,
+		SquaredIntervalSum: st.history.squaredIntervalSum,
+		Timestamp:          st.timestamp,
+		SampleCount:        st.sampleCount,
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Restore replaces the detector's state with a previously captured Snapshot. It
+// returns ErrSnapshotVersionMismatch if the snapshot was produced by a different
+// snapshotVersion, and ErrSnapshotConfigMismatch if the snapshot's maxSampleSize
+// or minStdDeviation don't match this detector's configuration.
+func (d *PhiAccuralFailureDetector) Restore(data []byte) error {
+	var s snapshot
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	if s.Version != snapshotVersion {
+		return ErrSnapshotVersionMismatch
+	}
+
+	if s.MaxSampleSize != d.maxSampleSize || s.MinStdDeviation != d.minStdDeviation {
+		return ErrSnapshotConfigMismatch
+	}
+
+	history := heartbeatHistory{
+		maxSampleSize:      d.maxSampleSize,
+		intervals:          s.Intervals,
+		intervalSum:        s.IntervalSum,
+		squaredIntervalSum: s.SquaredIntervalSum,
+	}
+
+	newState := &state{history: history, timestamp: s.Timestamp, sampleCount: s.SampleCount}
+
+	// publish through the same atomic CAS as Heartbeat, since state is also read
+	// via loadState from concurrent goroutines
+	for {
+		if d.casState(d.loadState(), newState) {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package failuredetector
+
+import (
+	"bytes"
+	"encoding/gob"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	fd.Heartbeat()
+	fd.Heartbeat()
+	fd.Heartbeat()
+
+	data, err := fd.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	restored, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := restored.Restore(data); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	if restored.SampleCount() != fd.SampleCount() {
+		t.Errorf("SampleCount() = %v, want %v", restored.SampleCount(), fd.SampleCount())
+	}
+
+	if !restored.IsAvailable() {
+		t.Error("restored detector should report resource available")
+	}
+}
+
+func TestRestoreRejectsConfigMismatch(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	data, err := fd.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	other, err := New(8.0, 500, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	if err := other.Restore(data); err != ErrSnapshotConfigMismatch {
+		t.Errorf("Restore() = %v, want ErrSnapshotConfigMismatch", err)
+	}
+}
+
+func TestRestoreRejectsVersionMismatch(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	data, err := fd.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	var s snapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	s.Version = snapshotVersion + 1
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	if err := fd.Restore(buf.Bytes()); err != ErrSnapshotVersionMismatch {
+		t.Errorf("Restore() = %v, want ErrSnapshotVersionMismatch", err)
+	}
+}
+
+// TestConcurrentHeartbeatAndRestore exercises Restore racing with Heartbeat under
+// -race: both must publish state via the same atomic CAS, or the race detector
+// flags a data race on the state pointer.
+func TestConcurrentHeartbeatAndRestore(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	data, err := fd.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			fd.Heartbeat()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := fd.Restore(data); err != nil {
+				t.Errorf("Restore: %v", err)
+			}
+		}
+	}()
+
+	wg.Wait()
+}
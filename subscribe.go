@@ -0,0 +1,206 @@
+package failuredetector
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Event is implemented by the values delivered on a channel returned by
+// Subscribe.
+type Event interface {
+	isEvent()
+}
+
+// Available is emitted when the detector transitions from unavailable to
+// available.
+type Available struct{}
+
+func (Available) isEvent() {}
+
+// Unavailable is emitted when the detector transitions from available to
+// unavailable.
+type Unavailable struct{}
+
+func (Unavailable) isEvent() {}
+
+// PhiCrossed is emitted whenever phi is recomputed, carrying its latest value.
+type PhiCrossed struct {
+	Value float64
+}
+
+func (PhiCrossed) isEvent() {}
+
+// HeartbeatLate is emitted when a heartbeat interval grows past half of the
+// acceptable heartbeat pause. It mirrors the legacy eventStream warning passed to
+// New, for subscribers that don't want to thread a separate channel through.
+type HeartbeatLate struct {
+	Interval time.Duration
+}
+
+func (HeartbeatLate) isEvent() {}
+
+// SkewRejected is emitted when HeartbeatAt discards a heartbeat because its
+// reported timestamp exceeded MaxClockSkew from the detector's current time.
+type SkewRejected struct {
+	Timestamp time.Time
+	Skew      time.Duration
+}
+
+func (SkewRejected) isEvent() {}
+
+// CancelFunc unsubscribes a channel previously returned by Subscribe and releases
+// its resources. It is safe to call more than once.
+type CancelFunc func()
+
+// subscriberBufferSize bounds how many events a slow subscriber can fall behind
+// by before further events are dropped rather than blocking the detector.
+const subscriberBufferSize = 16
+
+type subscriber struct {
+	ch      chan Event
+	dropped uint64
+}
+
+// Subscribe registers a new subscriber and returns a channel of state-transition
+// events along with a CancelFunc to unsubscribe. Delivery is non-blocking: a slow
+// consumer that doesn't drain its channel has events dropped rather than blocking
+// the detector or other subscribers; DroppedEvents reports how many the current
+// subscriptions have lost in total. The first subscriber starts an internal ticker so that a
+// resource that stops heartbeating can still be declared Unavailable without a new
+// Heartbeat call.
+func (d *PhiAccuralFailureDetector) Subscribe() (<-chan Event, CancelFunc) {
+	sub := &subscriber{ch: make(chan Event, subscriberBufferSize)}
+
+	d.subsMu.Lock()
+	id := d.nextSubID
+	d.nextSubID++
+	d.subs[id] = sub
+	d.startTickerLocked()
+	d.subsMu.Unlock()
+
+	var cancelled bool
+
+	return sub.ch, func() {
+		d.subsMu.Lock()
+		if !cancelled {
+			cancelled = true
+			delete(d.subs, id)
+			d.stopTickerIfIdleLocked()
+		}
+		d.subsMu.Unlock()
+	}
+}
+
+// DroppedEvents returns the total number of events dropped across all current
+// subscribers because they weren't draining their channel fast enough.
+func (d *PhiAccuralFailureDetector) DroppedEvents() uint64 {
+	d.subsMu.Lock()
+	defer d.subsMu.Unlock()
+
+	var total uint64
+	for _, s := range d.subs {
+		total += atomic.LoadUint64(&s.dropped)
+	}
+
+	return total
+}
+
+func (d *PhiAccuralFailureDetector) startTickerLocked() {
+	if d.tickerStop != nil {
+		return
+	}
+
+	interval := d.acceptableHeartbeatPause / 4
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	stop := make(chan struct{})
+	d.tickerStop = stop
+
+	go d.tickLoop(interval, stop)
+}
+
+func (d *PhiAccuralFailureDetector) stopTickerIfIdleLocked() {
+	if len(d.subs) == 0 && d.tickerStop != nil {
+		close(d.tickerStop)
+		d.tickerStop = nil
+	}
+}
+
+func (d *PhiAccuralFailureDetector) tickLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			d.notify(d.clock.Now(), 0)
+		}
+	}
+}
+
+// notify publishes PhiCrossed for the current phi value, an Available/Unavailable
+// transition if availability changed since the previous notify, and
+// HeartbeatLate if lateInterval is non-zero. It is called from both Heartbeat and
+// the internal ticker.
+func (d *PhiAccuralFailureDetector) notify(now time.Time, lateInterval time.Duration) {
+	phiValue := d.phiAt(now)
+	available := phiValue < d.threshold
+
+	d.subsMu.Lock()
+	if len(d.subs) == 0 {
+		d.subsMu.Unlock()
+		return
+	}
+
+	wasAvailable, known := d.lastAvailable, d.lastAvailableOK
+	d.lastAvailable = available
+	d.lastAvailableOK = true
+
+	subs := make([]*subscriber, 0, len(d.subs))
+	for _, s := range d.subs {
+		subs = append(subs, s)
+	}
+	d.subsMu.Unlock()
+
+	deliver(subs, PhiCrossed{Value: phiValue})
+
+	if known && available != wasAvailable {
+		if available {
+			deliver(subs, Available{})
+		} else {
+			deliver(subs, Unavailable{})
+		}
+	}
+
+	if lateInterval > 0 {
+		deliver(subs, HeartbeatLate{Interval: lateInterval})
+	}
+}
+
+// publishSkewRejected delivers a SkewRejected event to current subscribers. It is
+// called directly from HeartbeatAt, bypassing notify, since a rejected heartbeat
+// never reaches the history and so has no phi/availability change to report.
+func (d *PhiAccuralFailureDetector) publishSkewRejected(ts time.Time, skew time.Duration) {
+	d.subsMu.Lock()
+	subs := make([]*subscriber, 0, len(d.subs))
+	for _, s := range d.subs {
+		subs = append(subs, s)
+	}
+	d.subsMu.Unlock()
+
+	deliver(subs, SkewRejected{Timestamp: ts, Skew: skew})
+}
+
+func deliver(subs []*subscriber, ev Event) {
+	for _, s := range subs {
+		select {
+		case s.ch <- ev:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package failuredetector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribeReceivesPhiCrossedOnHeartbeat(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	events, cancel := fd.Subscribe()
+	defer cancel()
+
+	fd.Heartbeat()
+
+	select {
+	case ev := <-events:
+		if _, ok := ev.(PhiCrossed); !ok {
+			t.Errorf("Subscribe() delivered %#v, want PhiCrossed", ev)
+		}
+	case <-time.After(time.Second):
+		t.Error("Subscribe() did not deliver an event after Heartbeat()")
+	}
+}
+
+// TestTickerDeclaresUnavailableWithoutNewHeartbeat exercises Subscribe's
+// headline promise: the internal ticker, not just Heartbeat, can notice a
+// resource has gone quiet and declare it Unavailable.
+func TestTickerDeclaresUnavailableWithoutNewHeartbeat(t *testing.T) {
+	fd, err := New(1.0, 1000, time.Millisecond, 20*time.Millisecond, 5*time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	events, cancel := fd.Subscribe()
+	defer cancel()
+
+	fd.Heartbeat()
+
+	for {
+		select {
+		case ev := <-events:
+			if _, ok := ev.(Unavailable); ok {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatal("ticker did not deliver Unavailable without a new Heartbeat")
+		}
+	}
+}
+
+func TestCancelFuncStopsDelivery(t *testing.T) {
+	fd, err := New(8.0, 1000, 10*time.Millisecond, 0, 1*time.Second, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	events, cancel := fd.Subscribe()
+	cancel()
+
+	fd.Heartbeat()
+
+	select {
+	case ev := <-events:
+		t.Errorf("Subscribe() delivered %#v after cancel", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}